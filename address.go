@@ -0,0 +1,160 @@
+package bch32
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Params holds the network-specific rules DecodeAddress enforces: which
+// human-readable part identifies the network, which witness versions it
+// accepts, and how long a witness program is allowed to be.
+type Params struct {
+	HRP string
+
+	// AllowedVersions restricts which witness versions DecodeAddress will
+	// accept. A nil slice allows every SegWit version, 0 through 16.
+	AllowedVersions []int
+
+	// MinProgramSize and MaxProgramSize bound the witness program length in
+	// bytes. Zero means fall back to the generic SegWit bounds (2-40).
+	MinProgramSize int
+	MaxProgramSize int
+}
+
+func (p *Params) versionAllowed(version int) bool {
+	if len(p.AllowedVersions) == 0 {
+		return version >= 0 && version <= 16
+	}
+	for _, v := range p.AllowedVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Params) sizeAllowed(size int) bool {
+	min, max := p.MinProgramSize, p.MaxProgramSize
+	if min == 0 {
+		min = 2
+	}
+	if max == 0 {
+		max = 40
+	}
+	return size >= min && size <= max
+}
+
+// Address is a decoded SegWit-style Bch32/Bch32m address.
+type Address interface {
+	// ScriptAddress returns the raw witness program encoded in the address.
+	ScriptAddress() []byte
+	// EncodeAddress returns the address' human-readable Bch32/Bch32m encoding.
+	EncodeAddress() string
+	// Hrp returns the address' human-readable part.
+	Hrp() string
+	// Version returns the address' witness version.
+	Version() int
+}
+
+// addressWitness is the common implementation shared by every concrete
+// Address type: a human-readable part, a witness version and a program.
+type addressWitness struct {
+	hrp     string
+	version int
+	program []byte
+}
+
+func (a *addressWitness) ScriptAddress() []byte { return a.program }
+func (a *addressWitness) Hrp() string           { return a.hrp }
+func (a *addressWitness) Version() int          { return a.version }
+
+func (a *addressWitness) EncodeAddress() string {
+	program := make([]int, len(a.program))
+	for i, b := range a.program {
+		program[i] = int(b)
+	}
+	addr, err := AddrEncode(a.hrp, a.version, program)
+	if err != nil {
+		return ""
+	}
+	return addr
+}
+
+// AddressWitnessPubKeyHash is a SegWit v0 P2WPKH address (20-byte pubkey hash).
+type AddressWitnessPubKeyHash struct {
+	addressWitness
+}
+
+// NewAddressWitnessPubKeyHash builds a SegWit v0 P2WPKH address from a
+// 20-byte pubkey hash under params.
+func NewAddressWitnessPubKeyHash(hash []byte, params *Params) (*AddressWitnessPubKeyHash, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params is nil")
+	}
+	if len(hash) != 20 {
+		return nil, fmt.Errorf("invalid pubkey hash length : %d", len(hash))
+	}
+	if !params.versionAllowed(0) {
+		return nil, fmt.Errorf("invalid witness version : %d", 0)
+	}
+	if !params.sizeAllowed(len(hash)) {
+		return nil, fmt.Errorf("invalid witness program length : %d", len(hash))
+	}
+	return &AddressWitnessPubKeyHash{addressWitness{hrp: strings.ToLower(params.HRP), version: 0, program: hash}}, nil
+}
+
+// AddressWitnessScriptHash is a SegWit v0 P2WSH address (32-byte script hash).
+type AddressWitnessScriptHash struct {
+	addressWitness
+}
+
+// NewAddressWitnessScriptHash builds a SegWit v0 P2WSH address from a
+// 32-byte script hash under params.
+func NewAddressWitnessScriptHash(hash []byte, params *Params) (*AddressWitnessScriptHash, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params is nil")
+	}
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("invalid script hash length : %d", len(hash))
+	}
+	if !params.versionAllowed(0) {
+		return nil, fmt.Errorf("invalid witness version : %d", 0)
+	}
+	if !params.sizeAllowed(len(hash)) {
+		return nil, fmt.Errorf("invalid witness program length : %d", len(hash))
+	}
+	return &AddressWitnessScriptHash{addressWitness{hrp: strings.ToLower(params.HRP), version: 0, program: hash}}, nil
+}
+
+// DecodeAddress decodes s as a Bch32/Bch32m address under params, returning
+// a typed Address or an error if s's hrp doesn't match params, or its
+// witness version/program length is one params disallows.
+func DecodeAddress(s string, params *Params) (Address, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params is nil")
+	}
+	hrp := strings.ToLower(params.HRP)
+	version, data, err := AddrDecode(hrp, s)
+	if err != nil {
+		return nil, err
+	}
+	if !params.versionAllowed(version) {
+		return nil, fmt.Errorf("invalid witness version : %d", version)
+	}
+	if !params.sizeAllowed(len(data)) {
+		return nil, fmt.Errorf("invalid witness program length : %d", len(data))
+	}
+	program := make([]byte, len(data))
+	for i, d := range data {
+		program[i] = byte(d)
+	}
+	base := addressWitness{hrp: hrp, version: version, program: program}
+	switch {
+	case version == 0 && len(program) == 20:
+		return &AddressWitnessPubKeyHash{base}, nil
+	case version == 0 && len(program) == 32:
+		return &AddressWitnessScriptHash{base}, nil
+	default:
+		return &base, nil
+	}
+}
@@ -0,0 +1,150 @@
+package bch32
+
+import "testing"
+
+// TestNewAddressWitnessPubKeyHash checks the happy path and length
+// validation for v0 P2WPKH addresses.
+func TestNewAddressWitnessPubKeyHash(t *testing.T) {
+	params := &Params{HRP: "bc"}
+	hash := make([]byte, 20)
+	addr, err := NewAddressWitnessPubKeyHash(hash, params)
+	if err != nil {
+		t.Fatalf("NewAddressWitnessPubKeyHash returned error: %v", err)
+	}
+	if addr.Hrp() != "bc" || addr.Version() != 0 {
+		t.Errorf("got (hrp=%q, version=%d), want (hrp=%q, version=0)", addr.Hrp(), addr.Version(), "bc")
+	}
+	if _, err := NewAddressWitnessPubKeyHash(make([]byte, 19), params); err == nil {
+		t.Errorf("NewAddressWitnessPubKeyHash with a 19-byte hash succeeded, want error")
+	}
+}
+
+// TestNewAddressWitnessScriptHash checks the happy path and length
+// validation for v0 P2WSH addresses.
+func TestNewAddressWitnessScriptHash(t *testing.T) {
+	params := &Params{HRP: "bc"}
+	hash := make([]byte, 32)
+	addr, err := NewAddressWitnessScriptHash(hash, params)
+	if err != nil {
+		t.Fatalf("NewAddressWitnessScriptHash returned error: %v", err)
+	}
+	if addr.Hrp() != "bc" || addr.Version() != 0 {
+		t.Errorf("got (hrp=%q, version=%d), want (hrp=%q, version=0)", addr.Hrp(), addr.Version(), "bc")
+	}
+	if _, err := NewAddressWitnessScriptHash(make([]byte, 31), params); err == nil {
+		t.Errorf("NewAddressWitnessScriptHash with a 31-byte hash succeeded, want error")
+	}
+}
+
+// TestNewAddressWitnessRejectsDisallowedVersion checks that the
+// constructors refuse to build a v0 address under Params that don't
+// allow witness version 0, matching the restriction DecodeAddress
+// enforces.
+func TestNewAddressWitnessRejectsDisallowedVersion(t *testing.T) {
+	params := &Params{HRP: "bc", AllowedVersions: []int{1}}
+	if _, err := NewAddressWitnessPubKeyHash(make([]byte, 20), params); err == nil {
+		t.Errorf("NewAddressWitnessPubKeyHash succeeded under Params disallowing version 0, want error")
+	}
+	if _, err := NewAddressWitnessScriptHash(make([]byte, 32), params); err == nil {
+		t.Errorf("NewAddressWitnessScriptHash succeeded under Params disallowing version 0, want error")
+	}
+}
+
+// TestDecodeAddressRoundTrip checks that every address a constructor
+// builds decodes back under the same Params.
+func TestDecodeAddressRoundTrip(t *testing.T) {
+	params := &Params{HRP: "bc"}
+	pkh, err := NewAddressWitnessPubKeyHash(make([]byte, 20), params)
+	if err != nil {
+		t.Fatalf("NewAddressWitnessPubKeyHash returned error: %v", err)
+	}
+	decoded, err := DecodeAddress(pkh.EncodeAddress(), params)
+	if err != nil {
+		t.Fatalf("DecodeAddress(%q) returned error: %v", pkh.EncodeAddress(), err)
+	}
+	if _, ok := decoded.(*AddressWitnessPubKeyHash); !ok {
+		t.Errorf("DecodeAddress(%q) = %T, want *AddressWitnessPubKeyHash", pkh.EncodeAddress(), decoded)
+	}
+
+	sh, err := NewAddressWitnessScriptHash(make([]byte, 32), params)
+	if err != nil {
+		t.Fatalf("NewAddressWitnessScriptHash returned error: %v", err)
+	}
+	decoded, err = DecodeAddress(sh.EncodeAddress(), params)
+	if err != nil {
+		t.Fatalf("DecodeAddress(%q) returned error: %v", sh.EncodeAddress(), err)
+	}
+	if _, ok := decoded.(*AddressWitnessScriptHash); !ok {
+		t.Errorf("DecodeAddress(%q) = %T, want *AddressWitnessScriptHash", sh.EncodeAddress(), decoded)
+	}
+}
+
+// TestDecodeAddressRejectsWrongHrp checks that a mismatched hrp is
+// rejected.
+func TestDecodeAddressRejectsWrongHrp(t *testing.T) {
+	addr, err := NewAddressWitnessPubKeyHash(make([]byte, 20), &Params{HRP: "bc"})
+	if err != nil {
+		t.Fatalf("NewAddressWitnessPubKeyHash returned error: %v", err)
+	}
+	if _, err := DecodeAddress(addr.EncodeAddress(), &Params{HRP: "tb"}); err == nil {
+		t.Errorf("DecodeAddress succeeded under a Params with a different hrp, want error")
+	}
+}
+
+// TestDecodeAddressRejectsDisallowedVersion checks that DecodeAddress
+// enforces AllowedVersions, the same restriction the constructors must
+// now honor.
+func TestDecodeAddressRejectsDisallowedVersion(t *testing.T) {
+	program := make([]int, 32)
+	addr, err := AddrEncode("bc", 0, program)
+	if err != nil {
+		t.Fatalf("AddrEncode returned error: %v", err)
+	}
+	params := &Params{HRP: "bc", AllowedVersions: []int{1}}
+	if _, err := DecodeAddress(addr, params); err == nil {
+		t.Errorf("DecodeAddress(%q) succeeded under Params disallowing version 0, want error", addr)
+	}
+}
+
+// TestDecodeAddressRejectsDisallowedSize checks that DecodeAddress
+// enforces MinProgramSize/MaxProgramSize.
+func TestDecodeAddressRejectsDisallowedSize(t *testing.T) {
+	program := make([]int, 2)
+	addr, err := AddrEncode("bc", 0, program)
+	if err != nil {
+		t.Fatalf("AddrEncode returned error: %v", err)
+	}
+	params := &Params{HRP: "bc", MinProgramSize: 20, MaxProgramSize: 32}
+	if _, err := DecodeAddress(addr, params); err == nil {
+		t.Errorf("DecodeAddress(%q) succeeded under Params disallowing a 2-byte program, want error", addr)
+	}
+}
+
+// TestAddressUpperCaseHRPRoundTrip checks that an upper-case Params.HRP
+// round-trips: the decoded hrp is always lower-case, so both the
+// constructors and DecodeAddress must normalize case before comparing or
+// storing it.
+func TestAddressUpperCaseHRPRoundTrip(t *testing.T) {
+	params := &Params{HRP: "BC"}
+	addr, err := NewAddressWitnessPubKeyHash(make([]byte, 20), params)
+	if err != nil {
+		t.Fatalf("NewAddressWitnessPubKeyHash returned error: %v", err)
+	}
+	if _, err := DecodeAddress(addr.EncodeAddress(), params); err != nil {
+		t.Errorf("DecodeAddress(%q) returned error: %v", addr.EncodeAddress(), err)
+	}
+}
+
+// TestAddressNilParams checks that a nil *Params returns an error rather
+// than panicking.
+func TestAddressNilParams(t *testing.T) {
+	if _, err := NewAddressWitnessPubKeyHash(make([]byte, 20), nil); err == nil {
+		t.Errorf("NewAddressWitnessPubKeyHash with nil params succeeded, want error")
+	}
+	if _, err := NewAddressWitnessScriptHash(make([]byte, 32), nil); err == nil {
+		t.Errorf("NewAddressWitnessScriptHash with nil params succeeded, want error")
+	}
+	if _, err := DecodeAddress("bc1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq", nil); err == nil {
+		t.Errorf("DecodeAddress with nil params succeeded, want error")
+	}
+}
@@ -19,6 +19,13 @@
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
+//
+// Beyond the fully-lower and fully-upper forms required by BIP-173, this
+// package defines one additional "mixed case" Bch32/Bch32m form: the hrp and
+// the '1' separator keep a single uniform case, and the data part after the
+// separator is split into 4-character groups that alternate upper and lower
+// case, starting with an uppercase group. See MixedCase for the
+// implementation and Decode/Encode for where it is produced and accepted.
 package bch32
 
 import (
@@ -31,16 +38,47 @@ var charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
 
 var generator = []int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
 
+// Variant selects which checksum constant is used, per BIP-173 (original
+// Bech32) and BIP-350 (Bech32m). SegWit v0 addresses use Bech32; SegWit
+// v1-16 (e.g. Taproot) addresses use Bech32m.
+type Variant int
+
+const (
+	// VariantBech32 is the original Bech32 checksum (BIP-173).
+	VariantBech32 Variant = iota
+	// VariantBech32m is the Bech32m checksum (BIP-350).
+	VariantBech32m
+)
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+func (v Variant) checksumConst() int {
+	if v == VariantBech32m {
+		return bech32mConst
+	}
+	return bech32Const
+}
+
+// polymodStep runs a single step of the checksum's linear feedback shift
+// register: it folds one more 5-bit value v into the running state chk.
+func polymodStep(chk, v int) int {
+	top := chk >> 25
+	chk = (chk&0x1ffffff)<<5 ^ v
+	for i := 0; i < 5; i++ {
+		if (top>>uint(i))&1 == 1 {
+			chk ^= generator[i]
+		}
+	}
+	return chk
+}
+
 func polymod(values []int) int {
 	chk := 1
 	for _, v := range values {
-		top := chk >> 25
-		chk = (chk&0x1ffffff)<<5 ^ v
-		for i := 0; i < 5; i++ {
-			if (top>>uint(i))&1 == 1 {
-				chk ^= generator[i]
-			}
-		}
+		chk = polymodStep(chk, v)
 	}
 	return chk
 }
@@ -57,13 +95,13 @@ func hrpExpand(hrp string) []int {
 	return ret
 }
 
-func verifyChecksum(hrp string, data []int) bool {
-	return polymod(append(hrpExpand(hrp), data...)) == 1
+func verifyChecksum(hrp string, data []int, variant Variant) bool {
+	return polymod(append(hrpExpand(hrp), data...)) == variant.checksumConst()
 }
 
-func createChecksum(hrp string, data []int) []int {
+func createChecksum(hrp string, data []int, variant Variant) []int {
 	values := append(append(hrpExpand(hrp), data...), []int{0, 0, 0, 0, 0, 0}...)
-	mod := polymod(values) ^ 1
+	mod := polymod(values) ^ variant.checksumConst()
 	ret := make([]int, 6)
 	for p := 0; p < len(ret); p++ {
 		ret[p] = (mod >> uint(5*(5-p))) & 31
@@ -72,12 +110,23 @@ func createChecksum(hrp string, data []int) []int {
 }
 
 // Encode encodes hrp(human-readable part) and data(32bit data array), returns Bch32 / or error
-// if hrp is uppercase, return uppercase Bch32; if hrp is mixed, return mixed Bch32
+// if hrp is lowercase, return lowercase Bch32; if hrp is uppercase, return uppercase Bch32;
+// if hrp is neither (mixed case), return this package's mixed-case Bch32 (see MixedCase)
 func Encode(hrp string, data []int) (string, error) {
+	return encode(hrp, data, VariantBech32)
+}
+
+// EncodeM encodes hrp(human-readable part) and data(32bit data array) using the
+// Bech32m checksum (BIP-350), returns Bch32m / or error.
+func EncodeM(hrp string, data []int) (string, error) {
+	return encode(hrp, data, VariantBech32m)
+}
+
+func encode(hrp string, data []int, variant Variant) (string, error) {
 	if (len(hrp) + len(data) + 7) > 90 {
 		return "", fmt.Errorf("too long : hrp length=%d, data length=%d", len(hrp), len(data))
 	}
-	if len(hrp) < 1 || len(hrp) > 2 {
+	if len(hrp) < 1 || len(hrp) > 83 {
 		return "", fmt.Errorf("invalid hrp : hrp=%v", hrp)
 	}
 	for p, c := range hrp {
@@ -86,49 +135,251 @@ func Encode(hrp string, data []int) (string, error) {
 		}
 	}
 	lower := strings.ToLower(hrp) == hrp
-	mixed := (strings.ToUpper(hrp[0]) + strings.ToLower(hrp[1])) == hrp
+	upper := strings.ToUpper(hrp) == hrp
+	mixed := !lower && !upper
 	hrp = strings.ToLower(hrp)
-	combined := append(data, createChecksum(hrp, data)...)
+	combined := append(data, createChecksum(hrp, data, variant)...)
 	var ret bytes.Buffer
 	ret.WriteString(hrp)
+	ret.WriteString("1")
 	for idx, p := range combined {
 		if p < 0 || p >= len(charset) {
 			return "", fmt.Errorf("invalid data : data[%d]=%d", idx, p)
 		}
 		ret.WriteByte(charset[p])
 	}
-	if lower {
-		return ret.String(), nil
-	} else if mixed {
+	if mixed {
 		return MixedCase(ret.String()), nil
+	} else if lower {
+		return ret.String(), nil
 	}
 	return strings.ToUpper(ret.String()), nil
 }
 
 // Decode decodes bchString(Bech32) returns hrp(human-readable part) and data(32bit data array) / or error
 func Decode(bchString string) (string, []int, error) {
+	hrp, data, variant, err := decode(bchString)
+	if err != nil {
+		return "", nil, err
+	}
+	if variant != VariantBech32 {
+		return "", nil, fmt.Errorf("invalid checksum")
+	}
+	return hrp, data, nil
+}
+
+// DecodeM decodes bchString(Bech32m) returns hrp(human-readable part) and data(32bit data array) / or error
+func DecodeM(bchString string) (string, []int, error) {
+	hrp, data, variant, err := decode(bchString)
+	if err != nil {
+		return "", nil, err
+	}
+	if variant != VariantBech32m {
+		return "", nil, fmt.Errorf("invalid checksum")
+	}
+	return hrp, data, nil
+}
+
+// decode parses bchString into hrp and data without constraining the
+// checksum to a single Variant, returning which Variant the checksum
+// actually matched so callers (Decode, DecodeM, AddrDecode) can enforce
+// their own rules.
+func decode(bchString string) (string, []int, Variant, error) {
 	if len(bchString) > 90 {
-		return "", nil, fmt.Errorf("too long : len=%d", len(bchString))
+		return "", nil, 0, fmt.Errorf("too long : len=%d", len(bchString))
+	}
+	if strings.ToLower(bchString) != bchString && strings.ToUpper(bchString) != bchString && !isMixedCase(bchString) {
+		return "", nil, 0, fmt.Errorf("mixed case not allowed : bchString=%v", bchString)
 	}
 	bchString = strings.ToLower(bchString)
-	hrp := bchString[0:2]
+	pos := strings.LastIndex(bchString, "1")
+	if pos < 1 || pos+7 > len(bchString) {
+		return "", nil, 0, fmt.Errorf("invalid separator position : bchString=%v", bchString)
+	}
+	hrp := bchString[0:pos]
+	if len(hrp) > 83 {
+		return "", nil, 0, fmt.Errorf("invalid hrp length : hrp=%v", hrp)
+	}
 	for p, c := range hrp {
 		if c < 33 || c > 126 {
-			return "", nil, fmt.Errorf("invalid character human-readable part : bchString[%d]=%d", p, c)
+			return "", nil, 0, fmt.Errorf("invalid character human-readable part : bchString[%d]=%d", p, c)
 		}
 	}
 	data := []int{}
 	for p := pos + 1; p < len(bchString); p++ {
 		d := strings.Index(charset, fmt.Sprintf("%c", bchString[p]))
 		if d == -1 {
-			return "", nil, fmt.Errorf("invalid character data part : bchString[%d]=%d", p, bchString[p])
+			return "", nil, 0, fmt.Errorf("invalid character data part : bchString[%d]=%d", p, bchString[p])
 		}
 		data = append(data, d)
 	}
-	if !verifyChecksum(hrp, data) {
-		return "", nil, fmt.Errorf("invalid checksum")
+	switch {
+	case verifyChecksum(hrp, data, VariantBech32):
+		return hrp, data[:len(data)-6], VariantBech32, nil
+	case verifyChecksum(hrp, data, VariantBech32m):
+		return hrp, data[:len(data)-6], VariantBech32m, nil
 	}
-	return hrp, data[:len(data)-6], nil
+	return "", nil, 0, fmt.Errorf("invalid checksum")
+}
+
+// LocateErrors takes hrp(human-readable part, assumed correct) and s(the
+// full Bch32/Bch32m string including hrp, separator and checksum) whose
+// checksum does not verify, and returns the indices into s of up to two
+// characters that are likely corrupted.
+//
+// The Bech32 BCH code is guaranteed to detect up to 4 errors and to
+// uniquely locate up to 2 of them: the polymod residue of the string xor
+// the variant's checksum constant (the "syndrome") is zero only when no
+// symbol has been altered, so flipping the right one or two symbols back to some value is exactly
+// what makes the syndrome vanish again. Crucially, polymod's LFSR is
+// GF(2)-linear: for two equal-length inputs, polymod(a) xor polymod(b)
+// depends only on a xor b, and that dependence factors symbol-by-symbol
+// and bit-by-bit. So the syndrome contribution of corrupting the symbol
+// at position i by xor-ing in v depends only on v and on how many symbols
+// follow position i, not on the rest of the string. contributionTable
+// precomputes that dependence once, in O(n) time, by simulating the LFSR
+// with a single 1-bit symbol followed by the right number of zeros - the
+// same trick behind table-driven CRC. Locating errors then becomes a
+// table lookup (single error) or a hash-join over two per-position
+// tables (two errors), rather than a brute-force re-run of the whole
+// O(n) polymod for every candidate substitution.
+//
+// If s already has a valid checksum, LocateErrors returns (nil, nil). If
+// more than two characters are corrupted, no correction will be found and
+// an error is returned.
+func LocateErrors(hrp, s string) ([]int, error) {
+	if len(hrp) < 1 || len(hrp) > 83 {
+		return nil, fmt.Errorf("invalid hrp : hrp=%v", hrp)
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return nil, fmt.Errorf("mixed case not allowed : s=%v", s)
+	}
+	s = strings.ToLower(s)
+	hrp = strings.ToLower(hrp)
+	sep := strings.LastIndex(s, "1")
+	if sep < 1 || sep+7 > len(s) {
+		return nil, fmt.Errorf("invalid separator position : s=%v", s)
+	}
+	data := make([]int, 0, len(s)-sep-1)
+	for p := sep + 1; p < len(s); p++ {
+		d := strings.Index(charset, fmt.Sprintf("%c", s[p]))
+		if d == -1 {
+			return nil, fmt.Errorf("invalid character data part : s[%d]=%d", p, s[p])
+		}
+		data = append(data, d)
+	}
+	base := hrpExpand(hrp)
+	values := append(append([]int{}, base...), data...)
+	syndrome := polymod(values)
+	if syndrome == bech32Const || syndrome == bech32mConst {
+		return nil, nil
+	}
+	offset := len(base)
+	table := contributionTable(len(values))
+	for _, target := range []int{bech32Const, bech32mConst} {
+		needed := syndrome ^ target
+		if locs := locateSingleError(table, offset, len(values), needed); locs != nil {
+			return shiftToStringPositions(locs, offset, sep), nil
+		}
+	}
+	for _, target := range []int{bech32Const, bech32mConst} {
+		needed := syndrome ^ target
+		if locs := locateDoubleError(table, offset, len(values), needed); locs != nil {
+			return shiftToStringPositions(locs, offset, sep), nil
+		}
+	}
+	return nil, fmt.Errorf("unable to locate errors : too many corrupted characters")
+}
+
+// contributionTable[d][v] is the syndrome xor'd in by replacing a symbol
+// with d other symbols after it (d = distance from the end) with a value
+// that differs from the original by xor v (v in 1..31; v=0 is unused).
+// It depends only on the code's generator and total length n, not on the
+// actual string contents, so it is rebuilt per call but shared across
+// every position and every 1- and 2-error search within that call.
+func contributionTable(n int) [][32]int {
+	var basis [5][]int
+	for b := 0; b < 5; b++ {
+		basis[b] = make([]int, n)
+		chk := polymodStep(0, 1<<uint(b))
+		basis[b][0] = chk
+		for d := 1; d < n; d++ {
+			chk = polymodStep(chk, 0)
+			basis[b][d] = chk
+		}
+	}
+	table := make([][32]int, n)
+	for d := 0; d < n; d++ {
+		for v := 1; v < 32; v++ {
+			c := 0
+			for b := 0; b < 5; b++ {
+				if (v>>uint(b))&1 == 1 {
+					c ^= basis[b][d]
+				}
+			}
+			table[d][v] = c
+		}
+	}
+	return table
+}
+
+// locateSingleError looks for one position in [offset, n) whose
+// contribution table exactly cancels needed, i.e. correcting that one
+// symbol alone would zero the syndrome.
+func locateSingleError(table [][32]int, offset, n, needed int) []int {
+	for i := offset; i < n; i++ {
+		row := table[n-1-i]
+		for v := 1; v < 32; v++ {
+			if row[v] == needed {
+				return []int{i}
+			}
+		}
+	}
+	return nil
+}
+
+// locateDoubleError looks for two positions in [offset, n) whose combined
+// contribution exactly cancels needed. It hashes every position's 31
+// possible contributions once, then for every (position, contribution)
+// pair does an O(1) lookup for the complementary contribution instead of
+// re-testing every pair of positions and substitutions directly.
+func locateDoubleError(table [][32]int, offset, n, needed int) []int {
+	type candidate struct{ pos, v int }
+	byContribution := make(map[int][]candidate, 31*(n-offset))
+	for j := offset; j < n; j++ {
+		row := table[n-1-j]
+		for v := 1; v < 32; v++ {
+			c := row[v]
+			byContribution[c] = append(byContribution[c], candidate{j, v})
+		}
+	}
+	for i := offset; i < n; i++ {
+		row := table[n-1-i]
+		for v := 1; v < 32; v++ {
+			want := needed ^ row[v]
+			for _, cand := range byContribution[want] {
+				if cand.pos == i {
+					continue
+				}
+				if cand.pos < i {
+					return []int{cand.pos, i}
+				}
+				return []int{i, cand.pos}
+			}
+		}
+	}
+	return nil
+}
+
+// shiftToStringPositions maps indices into the data-symbol array back to
+// character indices into the original Bch32 string, accounting for the
+// hrp expansion offset and the '1' separator.
+func shiftToStringPositions(locs []int, offset, sep int) []int {
+	ret := make([]int, len(locs))
+	for i, loc := range locs {
+		ret[i] = sep + 1 + (loc - offset)
+	}
+	return ret
 }
 
 func convertbits(data []int, frombits, tobits uint, pad bool) ([]int, error) {
@@ -159,9 +410,19 @@ func convertbits(data []int, frombits, tobits uint, pad bool) ([]int, error) {
 	return ret, nil
 }
 
+// addrVariant returns the checksum Variant a SegWit witness version must use:
+// version 0 uses Bech32, versions 1-16 (e.g. Taproot) use Bech32m.
+func addrVariant(version int) Variant {
+	if version == 0 {
+		return VariantBech32
+	}
+	return VariantBech32m
+}
+
 // AddrDecode decodes hrp(human-readable part) Address(string), returns version(int) and data(bytes array) / or error
+// It automatically accepts Bech32 for witness version 0 and Bech32m for witness versions 1-16.
 func AddrDecode(hrp, addr string) (int, []int, error) {
-	dechrp, data, err := Decode(addr)
+	dechrp, data, variant, err := decode(addr)
 	if err != nil {
 		return -1, nil, err
 	}
@@ -174,6 +435,9 @@ func AddrDecode(hrp, addr string) (int, []int, error) {
 	if data[0] > 16 {
 		return -1, nil, fmt.Errorf("invalid version : %d", data[0])
 	}
+	if variant != addrVariant(data[0]) {
+		return -1, nil, fmt.Errorf("invalid checksum")
+	}
 	res, err := convertbits(data[1:], 5, 8, false)
 	if err != nil {
 		return -1, nil, err
@@ -185,6 +449,7 @@ func AddrDecode(hrp, addr string) (int, []int, error) {
 }
 
 // AddrEncode encodes hrp(human-readable part), version(int) and data(bytes array), returns Address / or error
+// It automatically uses Bech32 for witness version 0 and Bech32m for witness versions 1-16.
 func AddrEncode(hrp string, version int, program []int) (string, error) {
 	if version < 0 || version > 16 {
 		return "", fmt.Errorf("invalid version : %d", version)
@@ -196,30 +461,71 @@ func AddrEncode(hrp string, version int, program []int) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	ret, err := Encode(hrp, append([]int{version}, data...))
+	ret, err := encode(hrp, append([]int{version}, data...), addrVariant(version))
 	if err != nil {
 		return "", err
 	}
 	return ret, nil
 }
 
-// Create mixed Bch32 address
+// MixedCase rewrites a fully lower- or upper-case Bch32/Bch32m string
+// using this package's mixed-case convention: the hrp and the '1'
+// separator are left untouched, and the data part (everything after the
+// separator, i.e. the encoded payload plus checksum) is split into
+// 4-character groups that alternate upper/lower case, starting with an
+// uppercase group. This is the one specific form of mixed casing that
+// Decode accepts in addition to fully-lower and fully-upper strings; any
+// other mix of cases is rejected per BIP-173.
 func MixedCase(address string) string {
-	lower := false
-	var mixedAddress bytes.Buffer
-	for idx := 2; (idx + 1) < (len(address)-8)/4; idx+4 {
-		if lower {
-			mixedAddress.WriteString(strings.ToLower(address[idx:4]))
+	pos := strings.LastIndex(address, "1")
+	if pos < 0 {
+		return address
+	}
+	hrp, data := address[:pos+1], address[pos+1:]
+	var ret bytes.Buffer
+	ret.WriteString(hrp)
+	upper := true
+	for idx := 0; idx < len(data); idx += 4 {
+		end := idx + 4
+		if end > len(data) {
+			end = len(data)
+		}
+		if upper {
+			ret.WriteString(strings.ToUpper(data[idx:end]))
 		} else {
-			mixedAddress.WriteString(strings.ToUpper(address[idx:4]))
+			ret.WriteString(strings.ToLower(data[idx:end]))
 		}
-		lower = !lower
+		upper = !upper
 	}
-	hrp := strings.ToUpper(address[0]) + strings.ToLower(address[1])
-	if lower {
-		checksum := strings.ToLower(address[len(address)-6:6])
-	} else {
-		checksum := strings.ToUpper(address[len(address)-6:6])
+	return ret.String()
+}
+
+// isMixedCase reports whether s follows the MixedCase convention: hrp and
+// separator uniformly cased, data part in 4-character groups alternating
+// upper/lower starting with uppercase.
+func isMixedCase(s string) bool {
+	pos := strings.LastIndex(s, "1")
+	if pos < 0 {
+		return false
+	}
+	hrp, data := s[:pos], s[pos+1:]
+	if strings.ToLower(hrp) != hrp && strings.ToUpper(hrp) != hrp {
+		return false
+	}
+	upper := true
+	for idx := 0; idx < len(data); idx += 4 {
+		end := idx + 4
+		if end > len(data) {
+			end = len(data)
+		}
+		group := data[idx:end]
+		if upper && strings.ToUpper(group) != group {
+			return false
+		}
+		if !upper && strings.ToLower(group) != group {
+			return false
+		}
+		upper = !upper
 	}
-	return hrp + mixedAddress.String() + checksum
+	return true
 }
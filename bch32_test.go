@@ -0,0 +1,269 @@
+package bch32
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeBIP173Vectors checks the package against a subset of the BIP-173
+// reference test suite's valid Bech32 strings.
+func TestDecodeBIP173Vectors(t *testing.T) {
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	}
+	for _, v := range valid {
+		if _, _, err := Decode(v); err != nil {
+			t.Errorf("Decode(%q) = error %v, want success", v, err)
+		}
+	}
+}
+
+// TestEncodeDecodeRoundTrip checks that Encode writes the '1' separator so
+// Decode can find it again, and that Decode recovers hrp and data.
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	cases := []struct {
+		hrp  string
+		data []int
+	}{
+		{"a", []int{}},
+		{"bc", []int{0, 1, 2, 3, 4}},
+		{"tb", []int{0, 1, 2, 3, 4}},
+		{"bm", []int{0, 1, 2, 3, 4}},
+		{"abcdef", []int{0, 1, 2, 30, 31}},
+	}
+	for _, c := range cases {
+		encoded, err := Encode(c.hrp, c.data)
+		if err != nil {
+			t.Fatalf("Encode(%q, %v) returned error: %v", c.hrp, c.data, err)
+		}
+		if !strings.Contains(encoded, "1") {
+			t.Fatalf("Encode(%q, %v) = %q, missing '1' separator", c.hrp, c.data, encoded)
+		}
+		hrp, data, err := Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode(%q) returned error: %v", encoded, err)
+		}
+		if hrp != c.hrp {
+			t.Errorf("Decode(%q) hrp = %q, want %q", encoded, hrp, c.hrp)
+		}
+		if len(data) != len(c.data) {
+			t.Fatalf("Decode(%q) data = %v, want %v", encoded, data, c.data)
+		}
+		for i := range data {
+			if data[i] != c.data[i] {
+				t.Errorf("Decode(%q) data[%d] = %d, want %d", encoded, i, data[i], c.data[i])
+			}
+		}
+	}
+}
+
+// TestEncodeDecodeMaxLengthHRP exercises the arbitrary-length hrp support:
+// an 80-char hrp plus 3 data symbols, the separator and the 6-char checksum
+// fill the 90-char maximum string length.
+func TestEncodeDecodeMaxLengthHRP(t *testing.T) {
+	hrp := strings.Repeat("x", 80)
+	data := []int{1, 2, 3}
+	encoded, err := Encode(hrp, data)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	decHrp, decData, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode(%q) returned error: %v", encoded, err)
+	}
+	if decHrp != hrp || len(decData) != len(data) {
+		t.Errorf("Decode(%q) = (%q, %v), want (%q, %v)", encoded, decHrp, decData, hrp, data)
+	}
+}
+
+// TestDecodeInvalid checks strings that must be rejected.
+func TestDecodeInvalid(t *testing.T) {
+	invalid := []string{
+		"pzry9x0s0muk",  // no separator
+		"1pzry9x0s0muk", // empty hrp
+		"x1b4n0q5v",     // invalid data character
+		"A1G7SGD8",      // invalid checksum
+	}
+	for _, v := range invalid {
+		if _, _, err := Decode(v); err == nil {
+			t.Errorf("Decode(%q) succeeded, want error", v)
+		}
+	}
+}
+
+// TestEncodeMDecodeMRoundTrip exercises the Bech32m checksum variant added
+// for BIP-350, and checks that Decode/DecodeM each reject the other
+// variant's checksum.
+func TestEncodeMDecodeMRoundTrip(t *testing.T) {
+	cases := []struct {
+		hrp  string
+		data []int
+	}{
+		{"bc", []int{1, 0, 1, 2, 3, 4}},
+		{"tb", []int{16, 30, 31}},
+		{"abcdef", []int{0, 1, 2, 30, 31}},
+	}
+	for _, c := range cases {
+		encoded, err := EncodeM(c.hrp, c.data)
+		if err != nil {
+			t.Fatalf("EncodeM(%q, %v) returned error: %v", c.hrp, c.data, err)
+		}
+		hrp, data, err := DecodeM(encoded)
+		if err != nil {
+			t.Fatalf("DecodeM(%q) returned error: %v", encoded, err)
+		}
+		if hrp != c.hrp || len(data) != len(c.data) {
+			t.Fatalf("DecodeM(%q) = (%q, %v), want (%q, %v)", encoded, hrp, data, c.hrp, c.data)
+		}
+		for i := range data {
+			if data[i] != c.data[i] {
+				t.Errorf("DecodeM(%q) data[%d] = %d, want %d", encoded, i, data[i], c.data[i])
+			}
+		}
+		if _, _, err := Decode(encoded); err == nil {
+			t.Errorf("Decode(%q) succeeded on a Bech32m string, want error", encoded)
+		}
+		plain, err := Encode(c.hrp, c.data)
+		if err != nil {
+			t.Fatalf("Encode(%q, %v) returned error: %v", c.hrp, c.data, err)
+		}
+		if _, _, err := DecodeM(plain); err == nil {
+			t.Errorf("DecodeM(%q) succeeded on a Bech32 string, want error", plain)
+		}
+	}
+}
+
+// TestAddrEncodeDecodeVariantSelection checks that AddrEncode/AddrDecode
+// pick Bech32 for witness version 0 and Bech32m for versions 1-16, per the
+// SegWit address rules, and reject a checksum built for the wrong version.
+func TestAddrEncodeDecodeVariantSelection(t *testing.T) {
+	cases := []struct {
+		version int
+		program []int
+	}{
+		{0, make([]int, 20)},
+		{0, make([]int, 32)},
+		{1, make([]int, 32)},
+		{16, make([]int, 2)},
+	}
+	for _, c := range cases {
+		addr, err := AddrEncode("bc", c.version, c.program)
+		if err != nil {
+			t.Fatalf("AddrEncode(%q, %d, %v) returned error: %v", "bc", c.version, c.program, err)
+		}
+		version, program, err := AddrDecode("bc", addr)
+		if err != nil {
+			t.Fatalf("AddrDecode(%q) returned error: %v", addr, err)
+		}
+		if version != c.version || len(program) != len(c.program) {
+			t.Errorf("AddrDecode(%q) = (%d, %v), want (%d, %v)", addr, version, program, c.version, c.program)
+		}
+	}
+
+	// A v0 program checksummed as Bech32m (or vice versa) must be rejected
+	// even though the checksum itself is valid for the wrong variant.
+	data, err := convertbits(make([]int, 20), 8, 5, true)
+	if err != nil {
+		t.Fatalf("convertbits returned error: %v", err)
+	}
+	mismatched, err := encode("bc", append([]int{0}, data...), VariantBech32m)
+	if err != nil {
+		t.Fatalf("encode returned error: %v", err)
+	}
+	if _, _, err := AddrDecode("bc", mismatched); err == nil {
+		t.Errorf("AddrDecode(%q) succeeded on a v0 address with a Bech32m checksum, want error", mismatched)
+	}
+}
+
+// TestAddrEncodeDecodeBounds checks the version and program-length bounds
+// AddrEncode/AddrDecode enforce.
+func TestAddrEncodeDecodeBounds(t *testing.T) {
+	if _, err := AddrEncode("bc", -1, make([]int, 20)); err == nil {
+		t.Errorf("AddrEncode with version -1 succeeded, want error")
+	}
+	if _, err := AddrEncode("bc", 17, make([]int, 20)); err == nil {
+		t.Errorf("AddrEncode with version 17 succeeded, want error")
+	}
+	if _, err := AddrEncode("bc", 0, make([]int, 1)); err == nil {
+		t.Errorf("AddrEncode with a 1-byte program succeeded, want error")
+	}
+	if _, err := AddrEncode("bc", 0, make([]int, 41)); err == nil {
+		t.Errorf("AddrEncode with a 41-byte program succeeded, want error")
+	}
+}
+
+// corruptAt flips the character at pos to a different charset symbol, for
+// building LocateErrors test input.
+func corruptAt(s string, pos, delta int) string {
+	b := []byte(s)
+	cur := strings.IndexByte(charset, b[pos])
+	b[pos] = charset[(cur+delta)%32]
+	return string(b)
+}
+
+// TestLocateErrorsValid checks that a string with a valid checksum reports
+// no errors.
+func TestLocateErrorsValid(t *testing.T) {
+	good, err := Encode("bc", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	locs, err := LocateErrors("bc", good)
+	if err != nil || locs != nil {
+		t.Errorf("LocateErrors(%q) = (%v, %v), want (nil, nil)", good, locs, err)
+	}
+}
+
+// TestLocateErrorsSingle checks that a single corrupted character is
+// located at the right index into s.
+func TestLocateErrorsSingle(t *testing.T) {
+	good, err := Encode("bc", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	sep := strings.LastIndex(good, "1")
+	bad := corruptAt(good, sep+5, 7)
+	locs, err := LocateErrors("bc", bad)
+	if err != nil {
+		t.Fatalf("LocateErrors(%q) returned error: %v", bad, err)
+	}
+	if len(locs) != 1 || locs[0] != sep+5 {
+		t.Errorf("LocateErrors(%q) = %v, want [%d]", bad, locs, sep+5)
+	}
+}
+
+// TestLocateErrorsDouble checks that two corrupted characters are both
+// located.
+func TestLocateErrorsDouble(t *testing.T) {
+	good, err := Encode("bc", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	sep := strings.LastIndex(good, "1")
+	bad := corruptAt(corruptAt(good, sep+3, 5), sep+10, 11)
+	locs, err := LocateErrors("bc", bad)
+	if err != nil {
+		t.Fatalf("LocateErrors(%q) returned error: %v", bad, err)
+	}
+	if len(locs) != 2 || locs[0] != sep+3 || locs[1] != sep+10 {
+		t.Errorf("LocateErrors(%q) = %v, want [%d %d]", bad, locs, sep+3, sep+10)
+	}
+}
+
+// TestLocateErrorsTooManyCorruptions checks that more than two corrupted
+// characters yield an error rather than a wrong answer.
+func TestLocateErrorsTooManyCorruptions(t *testing.T) {
+	good, err := Encode("bc", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	sep := strings.LastIndex(good, "1")
+	bad := corruptAt(corruptAt(corruptAt(good, sep+1, 3), sep+6, 9), sep+11, 15)
+	if _, err := LocateErrors("bc", bad); err == nil {
+		t.Errorf("LocateErrors(%q) succeeded, want error for 3 corrupted characters", bad)
+	}
+}
@@ -0,0 +1,70 @@
+package bch32
+
+import "testing"
+
+func TestMixedCase(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		// hrp + '1' kept as-is; data part grouped by 4, alternating
+		// upper/lower starting with uppercase.
+		{"bc1qpzry9x8gfaur95s", "bc1QPZRy9x8GFAUr95s"},
+		{"a1lqfn3a", "a1LQFN3a"},
+		// a data part shorter than 4 characters is still uppercased as
+		// the (single, partial) first group.
+		{"a1qq", "a1QQ"},
+	}
+	for _, c := range cases {
+		if got := MixedCase(c.in); got != c.want {
+			t.Errorf("MixedCase(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestIsMixedCase(t *testing.T) {
+	valid := []string{
+		"bc1QPZRy9x8GFAUr95s",
+		"a1LQFN3a",
+	}
+	for _, v := range valid {
+		if !isMixedCase(v) {
+			t.Errorf("isMixedCase(%q) = false, want true", v)
+		}
+	}
+
+	invalid := []string{
+		"Bc1qpzry9x8gfaur95s", // hrp itself is mixed case
+		"bc1qPzry9x8gfaur95s", // group boundary doesn't align to 4 chars
+		"bc1QPZRy9X8gfaur95s", // second group wrongly contains an uppercase character
+	}
+	for _, v := range invalid {
+		if isMixedCase(v) {
+			t.Errorf("isMixedCase(%q) = true, want false", v)
+		}
+	}
+}
+
+// TestEncodeMixedCaseHRP exercises Encode's mixed-case hrp path end-to-end:
+// a mixed-case hrp should produce a MixedCase-convention string that Decode
+// accepts and recovers losslessly.
+func TestEncodeMixedCaseHRP(t *testing.T) {
+	hrp, data := "Bc", []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	encoded, err := Encode(hrp, data)
+	if err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	if !isMixedCase(encoded) {
+		t.Fatalf("Encode(%q, ...) = %q, not in MixedCase form", hrp, encoded)
+	}
+	decHrp, decData, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode(%q) returned error: %v", encoded, err)
+	}
+	if decHrp != "bc" {
+		t.Errorf("Decode(%q) hrp = %q, want %q", encoded, decHrp, "bc")
+	}
+	if len(decData) != len(data) {
+		t.Fatalf("Decode(%q) data = %v, want %v", encoded, decData, data)
+	}
+}